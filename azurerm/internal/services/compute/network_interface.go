@@ -2,13 +2,102 @@ package compute
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"sync"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-07-01/compute"
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/helpers/azure"
+	"golang.org/x/sync/errgroup"
 )
 
+// NOTE: the connection-interface selection, computed IP/DNS attributes, and VMSS connection-info helpers in
+// this file are not yet called from azurerm_linux_virtual_machine/azurerm_windows_virtual_machine,
+// azurerm_virtual_machine_scale_set_extension, or the VMSS VM data source - wiring them into those resources
+// is scoped to a follow-up series, not this one.
+
+// defaultNICFetchConcurrency is the number of Network Interfaces that `retrieveConnectionInformation`
+// fetches in parallel when it's called with a `concurrency` of zero or less; no provider `features`
+// block setting is read for this
+const defaultNICFetchConcurrency = 8
+
+// nicConnectionCache is a short-lived cache of resolved Network Interface details, keyed by NIC ID.
+// Callers should create one cache per Terraform apply/refresh and reuse it across resources so that
+// a NIC shared between multiple Virtual Machines is served from the cache once it has been fetched.
+// Lookups are not de-duplicated while a fetch is in flight: concurrent lookups of the same NIC that
+// miss the cache each fetch it from the Network API.
+type nicConnectionCache struct {
+	mu      sync.Mutex
+	entries map[string]*interfaceDetails
+}
+
+// newNICConnectionCache returns an empty, ready to use `nicConnectionCache`
+func newNICConnectionCache() *nicConnectionCache {
+	return &nicConnectionCache{
+		entries: make(map[string]*interfaceDetails),
+	}
+}
+
+func (c *nicConnectionCache) get(nicID string) (*interfaceDetails, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	details, ok := c.entries[nicID]
+	return details, ok
+}
+
+func (c *nicConnectionCache) set(nicID string, details *interfaceDetails) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[nicID] = details
+}
+
+// connectionInterface is the value of the `connection_interface` field, controlling which
+// NIC/IP Terraform Provisioners connect to when multiple are attached to a Virtual Machine
+type connectionInterface string
+
+const (
+	// connectionInterfaceAuto preserves the historical behaviour of preferring the Primary
+	// Public IP Address and falling back to the Primary Private IP Address
+	connectionInterfaceAuto connectionInterface = "auto"
+
+	// connectionInterfacePublicIP restricts the connection host to the Primary Public IP Address
+	connectionInterfacePublicIP connectionInterface = "public_ip"
+
+	// connectionInterfacePrivateIP restricts the connection host to the Primary Private IP Address
+	connectionInterfacePrivateIP connectionInterface = "private_ip"
+
+	// connectionInterfacePublicIPv6 prefers the Primary Public IPv6 Address as the connection host,
+	// falling back to the Primary Public IP Address when there's no IPv6 one
+	connectionInterfacePublicIPv6 connectionInterface = "public_ipv6"
+
+	// connectionInterfacePrivateIPv6 prefers the Primary Private IPv6 Address as the connection host,
+	// falling back to the Primary Private IP Address when there's no IPv6 one
+	connectionInterfacePrivateIPv6 connectionInterface = "private_ipv6"
+
+	// connectionInterfacePublicDNS prefers the Primary Public IP's DNS FQDN as the connection host,
+	// falling back to the Primary Public IP Address when no FQDN is set
+	connectionInterfacePublicDNS connectionInterface = "public_dns"
+
+	// connectionInterfacePrivateDNS prefers the Primary NIC's internal DNS name as the connection host,
+	// falling back to the Primary Private IP Address when there's no internal DNS name
+	connectionInterfacePrivateDNS connectionInterface = "private_dns"
+)
+
+// connectionIPSelector describes how the connection host should be determined when a
+// Virtual Machine has more than one Network Interface attached
+type connectionIPSelector struct {
+	// interfaceType is one of the `connectionInterface` constants above
+	interfaceType connectionInterface
+
+	// nicName, when set, restricts IP Address collection to the Network Interface with this name
+	// rather than every NIC attached to the Virtual Machine
+	nicName string
+}
+
 type connectionInfo struct {
 	// primaryPrivateAddress is the Primary Private IP Address for this VM
 	primaryPrivateAddress string
@@ -21,64 +110,348 @@ type connectionInfo struct {
 
 	// publicAddresses is a slice of the Public IP Addresses supported by this VM
 	publicAddresses []string
+
+	// primaryPrivateAddressV6 is the Primary Private IPv6 Address for this VM
+	primaryPrivateAddressV6 string
+
+	// privateAddressesV6 is a slice of the Private IPv6 Addresses supported by this VM
+	privateAddressesV6 []string
+
+	// primaryPublicAddressV6 is the Primary Public IPv6 Address for this VM
+	primaryPublicAddressV6 string
+
+	// publicAddressesV6 is a slice of the Public IPv6 Addresses supported by this VM
+	publicAddressesV6 []string
+
+	// publicDNSNames is a slice of the Public IP DNS FQDNs (`DnsSettings.Fqdn`) supported by this VM
+	publicDNSNames []string
+
+	// primaryPublicDNSName is the DNS FQDN of the Primary Public IP Address for this VM, if one is set
+	primaryPublicDNSName string
+
+	// primaryPrivateDNSName is the internal DNS name of the Primary NIC for this VM (its name combined
+	// with the NIC's `InternalDomainNameSuffix`), if the NIC's DNS settings expose one
+	primaryPrivateDNSName string
+}
+
+// matchesConnectionSelector reports whether a NIC with the given name is included by `selector` -
+// every NIC matches when `selector` is nil or its `nicName` is unset
+func matchesConnectionSelector(nicName string, selector *connectionIPSelector) bool {
+	return selector == nil || selector.nicName == "" || nicName == selector.nicName
 }
 
-// retrieveConnectionInformation retrieves all of the Public and Private IP Addresses assigned to a Virtual Machine
-func retrieveConnectionInformation(ctx context.Context, client *network.InterfacesClient, input *compute.VirtualMachineProperties) connectionInfo {
+// connectionInterfaceSchema returns the `connection_interface` schema fragment shared by
+// `azurerm_linux_virtual_machine` and `azurerm_windows_virtual_machine`, letting users pin the
+// Provisioner connection host to a specific IP family/DNS name rather than relying on the `auto`
+// default (Primary Public IP, falling back to the Primary Private IP).
+func connectionInterfaceSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeString,
+		Optional: true,
+		Default:  string(connectionInterfaceAuto),
+		ValidateFunc: validation.StringInSlice([]string{
+			string(connectionInterfaceAuto),
+			string(connectionInterfacePublicIP),
+			string(connectionInterfacePrivateIP),
+			string(connectionInterfacePublicIPv6),
+			string(connectionInterfacePrivateIPv6),
+			string(connectionInterfacePublicDNS),
+			string(connectionInterfacePrivateDNS),
+		}, false),
+	}
+}
+
+// connectionInterfaceNICNameSchema returns the `connection_interface_nic_name` schema fragment used
+// alongside `connectionInterfaceSchema` to restrict IP/DNS collection to a single Network Interface
+// when more than one is attached to the Virtual Machine.
+func connectionInterfaceNICNameSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeString,
+		Optional: true,
+	}
+}
+
+// expandConnectionIPSelector reads the `connection_interface` and `connection_interface_nic_name`
+// fields off the given ResourceData into a `connectionIPSelector` for use with
+// `retrieveConnectionInformation`/`setConnectionInformation`.
+func expandConnectionIPSelector(d *schema.ResourceData) *connectionIPSelector {
+	return &connectionIPSelector{
+		interfaceType: connectionInterface(d.Get("connection_interface").(string)),
+		nicName:       d.Get("connection_interface_nic_name").(string),
+	}
+}
+
+// ipAddressAttributesSchema returns the computed IP/DNS attributes schema fragment populated by
+// `setIPAddressAttributes`; not yet merged into any resource's Schema (see the file-level NOTE above)
+func ipAddressAttributesSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"private_ip_address": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"private_ip_addresses": {
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+		"public_ip_address": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"public_ip_addresses": {
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+		"private_ip_addresses_v6": {
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+		"public_ip_addresses_v6": {
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+		"public_ip_dns_names": { // not yet part of a resource's Schema - see the file-level NOTE above
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+	}
+}
+
+// retrieveConnectionInformation retrieves all of the Public and Private IP Addresses assigned to a Virtual
+// Machine, optionally restricted to a single Network Interface by `selector`, fanned out up to `concurrency`
+// at a time and served from `cache` where possible
+func retrieveConnectionInformation(ctx context.Context, client *network.InterfacesClient, input *compute.VirtualMachineProperties, selector *connectionIPSelector, cache *nicConnectionCache, concurrency int) (connectionInfo, error) {
 	if input == nil || input.NetworkProfile == nil || input.NetworkProfile.NetworkInterfaces == nil {
-		return connectionInfo{}
+		return connectionInfo{}, nil
 	}
 
-	privateIPAddresses := make([]string, 0)
-	publicIPAddresses := make([]string, 0)
+	if concurrency <= 0 {
+		concurrency = defaultNICFetchConcurrency
+	}
+
+	type nicTarget struct {
+		id        string
+		name      string
+		isPrimary bool
+	}
+
+	targets := make([]nicTarget, 0)
+	for _, v := range *input.NetworkProfile.NetworkInterfaces {
+		if v.ID == nil {
+			continue
+		}
+
+		id, err := azure.ParseAzureResourceID(*v.ID)
+		if err != nil {
+			continue
+		}
+		nicName := id.Path["networkInterfaces"]
+
+		if !matchesConnectionSelector(nicName, selector) {
+			continue
+		}
+
+		// the `Primary` flag on the NIC reference identifies the Network Interface that's actually
+		// primary on the Virtual Machine - this is not necessarily the first NIC in the list
+		isPrimary := v.NetworkInterfaceReferenceProperties != nil && v.NetworkInterfaceReferenceProperties.Primary != nil && *v.NetworkInterfaceReferenceProperties.Primary
+
+		targets = append(targets, nicTarget{id: *v.ID, name: nicName, isPrimary: isPrimary})
+	}
+
+	nics := make([]*nicConnectionDetails, len(targets))
+	sem := make(chan struct{}, concurrency)
+	var g errgroup.Group
+	var mu sync.Mutex
+	var errs *multierror.Error
+
+	for i, target := range targets {
+		i, target := i, target
+		g.Go(func() error {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if cache != nil {
+				if cached, ok := cache.get(target.id); ok {
+					nics[i] = &nicConnectionDetails{name: target.name, isPrimary: target.isPrimary, details: cached}
+					return nil
+				}
+			}
+
+			details, err := retrieveIPAddressesForNIC(ctx, client, target.id)
+			if err != nil {
+				mu.Lock()
+				errs = multierror.Append(errs, err)
+				mu.Unlock()
+				return nil
+			}
+
+			if cache != nil {
+				cache.set(target.id, details)
+			}
+
+			nics[i] = &nicConnectionDetails{name: target.name, isPrimary: target.isPrimary, details: details}
+			return nil
+		})
+	}
+
+	// errors are collected into `errs` rather than returned, so every NIC still gets fetched
+	_ = g.Wait()
+
+	// skip NICs that failed to fetch rather than discarding every other NIC's addresses too
+	resolved := make([]nicConnectionDetails, 0, len(nics))
+	for _, nic := range nics {
+		if nic == nil {
+			continue
+		}
+		resolved = append(resolved, *nic)
+	}
+
+	return buildConnectionInfo(resolved), errs.ErrorOrNil()
+}
+
+// retrieveScaleSetVMConnectionInformation retrieves all of the Public and Private IP Addresses assigned to a
+// Virtual Machine Scale Set instance; not yet called from azurerm_virtual_machine_scale_set_extension or the
+// VMSS VM data source (see the file-level NOTE above)
+func retrieveScaleSetVMConnectionInformation(ctx context.Context, client *compute.VirtualMachineScaleSetVMNetworkInterfacesClient, resourceGroup, vmScaleSetName, instanceID string, selector *connectionIPSelector) (connectionInfo, error) {
+	result, err := client.List(ctx, resourceGroup, vmScaleSetName, instanceID)
+	if err != nil {
+		return connectionInfo{}, fmt.Errorf("retrieving Network Interfaces for VM Scale Set %q (Resource Group %q / Instance %q): %+v", vmScaleSetName, resourceGroup, instanceID, err)
+	}
 
-	if input != nil && input.NetworkProfile != nil && input.NetworkProfile.NetworkInterfaces != nil {
-		for _, v := range *input.NetworkProfile.NetworkInterfaces {
-			if v.ID == nil {
+	nics := make([]nicConnectionDetails, 0)
+	for result.NotDone() {
+		for _, nic := range result.Values() {
+			if nic.Name == nil {
 				continue
 			}
 
-			nic := retrieveIPAddressesForNIC(ctx, client, *v.ID)
-			if nic == nil {
+			if !matchesConnectionSelector(*nic.Name, selector) {
 				continue
 			}
 
-			privateIPAddresses = append(privateIPAddresses, nic.privateIPAddresses...)
-			publicIPAddresses = append(publicIPAddresses, nic.publicIPAddresses...)
+			isPrimary := nic.InterfacePropertiesFormat != nil && nic.InterfacePropertiesFormat.Primary != nil && *nic.InterfacePropertiesFormat.Primary
+
+			nics = append(nics, nicConnectionDetails{
+				name:      *nic.Name,
+				isPrimary: isPrimary,
+				details:   extractInterfaceDetails(nic),
+			})
+		}
+
+		if err := result.NextWithContext(ctx); err != nil {
+			return connectionInfo{}, fmt.Errorf("iterating Network Interfaces for VM Scale Set %q (Resource Group %q / Instance %q): %+v", vmScaleSetName, resourceGroup, instanceID, err)
 		}
 	}
 
-	primaryPrivateAddress := ""
-	if len(privateIPAddresses) > 0 {
-		primaryPrivateAddress = privateIPAddresses[0]
+	return buildConnectionInfo(nics), nil
+}
+
+// buildConnectionInfo orders the given NICs primary-first then by name, and flattens their
+// addresses into a `connectionInfo`
+func buildConnectionInfo(nics []nicConnectionDetails) connectionInfo {
+	sort.Slice(nics, func(i, j int) bool {
+		if nics[i].isPrimary != nics[j].isPrimary {
+			return nics[i].isPrimary
+		}
+		return nics[i].name < nics[j].name
+	})
+
+	privateIPAddresses := make([]string, 0)
+	publicIPAddresses := make([]string, 0)
+	privateIPAddressesV6 := make([]string, 0)
+	publicIPAddressesV6 := make([]string, 0)
+	publicDNSNames := make([]string, 0)
+	for _, nic := range nics {
+		privateIPAddresses = append(privateIPAddresses, nic.details.privateIPAddresses...)
+		publicIPAddresses = append(publicIPAddresses, nic.details.publicIPAddresses...)
+		privateIPAddressesV6 = append(privateIPAddressesV6, nic.details.privateIPAddressesV6...)
+		publicIPAddressesV6 = append(publicIPAddressesV6, nic.details.publicIPAddressesV6...)
+		publicDNSNames = append(publicDNSNames, nic.details.publicDNSNames...)
 	}
+
+	primaryPrivateAddress := ""
 	primaryPublicAddress := ""
-	if len(publicIPAddresses) > 0 {
-		primaryPublicAddress = publicIPAddresses[0]
+	primaryPrivateAddressV6 := ""
+	primaryPublicAddressV6 := ""
+	primaryPublicDNSName := ""
+	primaryPrivateDNSName := ""
+	if len(nics) > 0 {
+		primaryNIC := nics[0]
+		primary := primaryNIC.details
+		if len(primary.privateIPAddresses) > 0 {
+			primaryPrivateAddress = primary.privateIPAddresses[0]
+		}
+		if len(primary.publicIPAddresses) > 0 {
+			primaryPublicAddress = primary.publicIPAddresses[0]
+		}
+		if len(primary.privateIPAddressesV6) > 0 {
+			primaryPrivateAddressV6 = primary.privateIPAddressesV6[0]
+		}
+		if len(primary.publicIPAddressesV6) > 0 {
+			primaryPublicAddressV6 = primary.publicIPAddressesV6[0]
+		}
+		if len(primary.publicDNSNames) > 0 {
+			primaryPublicDNSName = primary.publicDNSNames[0]
+		}
+		if primary.internalDomainNameSuffix != "" && primaryNIC.name != "" {
+			primaryPrivateDNSName = fmt.Sprintf("%s.%s", primaryNIC.name, primary.internalDomainNameSuffix)
+		}
 	}
 
 	return connectionInfo{
-		primaryPrivateAddress: primaryPrivateAddress,
-		privateAddresses:      privateIPAddresses,
-		primaryPublicAddress:  primaryPublicAddress,
-		publicAddresses:       publicIPAddresses,
+		primaryPrivateAddress:   primaryPrivateAddress,
+		privateAddresses:        privateIPAddresses,
+		primaryPublicAddress:    primaryPublicAddress,
+		publicAddresses:         publicIPAddresses,
+		primaryPrivateAddressV6: primaryPrivateAddressV6,
+		privateAddressesV6:      privateIPAddressesV6,
+		primaryPublicAddressV6:  primaryPublicAddressV6,
+		publicAddressesV6:       publicIPAddressesV6,
+		publicDNSNames:          publicDNSNames,
+		primaryPublicDNSName:    primaryPublicDNSName,
+		primaryPrivateDNSName:   primaryPrivateDNSName,
 	}
 }
 
+// nicConnectionDetails pairs a Network Interface's resolved IP Addresses with the information
+// needed to order NICs deterministically (primary first, then name)
+type nicConnectionDetails struct {
+	name      string
+	isPrimary bool
+	details   *interfaceDetails
+}
+
 type interfaceDetails struct {
-	// privateIPAddresses is a slice of the Private IP Addresses supported by this VM
+	// privateIPAddresses is a slice of the Private IPv4 Addresses assigned to this NIC
 	privateIPAddresses []string
 
-	// publicIPAddresses is a slice of the Public IP Addresses supported by this VM
+	// publicIPAddresses is a slice of the Public IPv4 Addresses assigned to this NIC
 	publicIPAddresses []string
+
+	// privateIPAddressesV6 is a slice of the Private IPv6 Addresses assigned to this NIC
+	privateIPAddressesV6 []string
+
+	// publicIPAddressesV6 is a slice of the Public IPv6 Addresses assigned to this NIC
+	publicIPAddressesV6 []string
+
+	// publicDNSNames is a slice of the Public IP DNS FQDNs (`DnsSettings.Fqdn`) assigned to this NIC
+	publicDNSNames []string
+
+	// internalDomainNameSuffix is this NIC's `DNSSettings.InternalDomainNameSuffix`, used together with
+	// the NIC's name to build its internal DNS name (e.g. `<nic-name>.<internalDomainNameSuffix>`)
+	internalDomainNameSuffix string
 }
 
-// retrieveIPAddressesForNIC returns the Public and Private IP Addresses associated
+// retrieveIPAddressesForNIC returns the Public and Private IPv4/IPv6 Addresses associated
 // with the specified Network Interface
-func retrieveIPAddressesForNIC(ctx context.Context, client *network.InterfacesClient, nicID string) *interfaceDetails {
+func retrieveIPAddressesForNIC(ctx context.Context, client *network.InterfacesClient, nicID string) (*interfaceDetails, error) {
 	id, err := azure.ParseAzureResourceID(nicID)
 	if err != nil {
-		return nil
+		return nil, fmt.Errorf("parsing ID for Network Interface %q: %+v", nicID, err)
 	}
 
 	resourceGroup := id.ResourceGroup
@@ -86,26 +459,56 @@ func retrieveIPAddressesForNIC(ctx context.Context, client *network.InterfacesCl
 
 	nic, err := client.Get(ctx, resourceGroup, name, "")
 	if err != nil {
-		return nil
+		return nil, fmt.Errorf("retrieving Network Interface %q (Resource Group %q): %+v", name, resourceGroup, err)
+	}
+
+	return extractInterfaceDetails(nic), nil
+}
+
+// extractInterfaceDetails pulls the Private/Public IPv4 and IPv6 Addresses out of an already-resolved
+// Network Interface
+func extractInterfaceDetails(nic network.Interface) *interfaceDetails {
+	if nic.InterfacePropertiesFormat == nil {
+		return &interfaceDetails{}
+	}
+
+	internalDomainNameSuffix := ""
+	if dnsSettings := nic.InterfacePropertiesFormat.DNSSettings; dnsSettings != nil && dnsSettings.InternalDomainNameSuffix != nil {
+		internalDomainNameSuffix = *dnsSettings.InternalDomainNameSuffix
 	}
 
-	if nic.InterfacePropertiesFormat == nil || nic.InterfacePropertiesFormat.IPConfigurations == nil {
-		return nil
+	if nic.InterfacePropertiesFormat.IPConfigurations == nil {
+		return &interfaceDetails{internalDomainNameSuffix: internalDomainNameSuffix}
 	}
 
 	privateIPAddresses := make([]string, 0)
 	publicIPAddresses := make([]string, 0)
+	privateIPAddressesV6 := make([]string, 0)
+	publicIPAddressesV6 := make([]string, 0)
+	publicDNSNames := make([]string, 0)
 	for _, config := range *nic.InterfacePropertiesFormat.IPConfigurations {
 		if props := config.InterfaceIPConfigurationPropertiesFormat; props != nil {
 
 			if props.PrivateIPAddress != nil {
-				privateIPAddresses = append(privateIPAddresses, *props.PrivateIPAddress)
+				if props.PrivateIPAddressVersion == network.IPv6 {
+					privateIPAddressesV6 = append(privateIPAddressesV6, *props.PrivateIPAddress)
+				} else {
+					privateIPAddresses = append(privateIPAddresses, *props.PrivateIPAddress)
+				}
 			}
 
 			if pip := props.PublicIPAddress; pip != nil {
 				if pipProps := pip.PublicIPAddressPropertiesFormat; pipProps != nil {
 					if pipProps.IPAddress != nil {
-						publicIPAddresses = append(publicIPAddresses, *pipProps.IPAddress)
+						if pipProps.PublicIPAddressVersion == network.IPv6 {
+							publicIPAddressesV6 = append(publicIPAddressesV6, *pipProps.IPAddress)
+						} else {
+							publicIPAddresses = append(publicIPAddresses, *pipProps.IPAddress)
+						}
+					}
+
+					if dnsSettings := pipProps.DNSSettings; dnsSettings != nil && dnsSettings.Fqdn != nil && *dnsSettings.Fqdn != "" {
+						publicDNSNames = append(publicDNSNames, *dnsSettings.Fqdn)
 					}
 				}
 			}
@@ -113,23 +516,61 @@ func retrieveIPAddressesForNIC(ctx context.Context, client *network.InterfacesCl
 	}
 
 	return &interfaceDetails{
-		privateIPAddresses: privateIPAddresses,
-		publicIPAddresses:  publicIPAddresses,
+		privateIPAddresses:       privateIPAddresses,
+		publicIPAddresses:        publicIPAddresses,
+		privateIPAddressesV6:     privateIPAddressesV6,
+		publicIPAddressesV6:      publicIPAddressesV6,
+		publicDNSNames:           publicDNSNames,
+		internalDomainNameSuffix: internalDomainNameSuffix,
 	}
 }
 
 // setConnectionInformation sets the connection information required for Provisioners
-// to connect to the Virtual Machine. A Public IP Address is used if one is available
-// but this falls back to a Private IP Address (which should always exist)
-func setConnectionInformation(d *schema.ResourceData, input connectionInfo, isWindows bool) {
+// to connect to the Virtual Machine. By default a Public IP Address is used if one is
+// available, falling back to a Private IP Address (which should always exist) - this
+// can be overridden via `selector` to pin the connection to a specific interface type.
+func setConnectionInformation(d *schema.ResourceData, input connectionInfo, isWindows bool, selector *connectionIPSelector) {
 	provisionerType := "ssh"
 	if isWindows {
 		provisionerType = "winrm"
 	}
 
-	ipAddress := input.primaryPublicAddress
-	if ipAddress == "" {
+	interfaceType := connectionInterfaceAuto
+	if selector != nil && selector.interfaceType != "" {
+		interfaceType = selector.interfaceType
+	}
+
+	ipAddress := ""
+	switch interfaceType {
+	case connectionInterfacePublicIP:
+		ipAddress = input.primaryPublicAddress
+	case connectionInterfacePrivateIP:
 		ipAddress = input.primaryPrivateAddress
+	case connectionInterfacePublicIPv6:
+		ipAddress = input.primaryPublicAddressV6
+		if ipAddress == "" {
+			ipAddress = input.primaryPublicAddress
+		}
+	case connectionInterfacePrivateIPv6:
+		ipAddress = input.primaryPrivateAddressV6
+		if ipAddress == "" {
+			ipAddress = input.primaryPrivateAddress
+		}
+	case connectionInterfacePublicDNS:
+		ipAddress = input.primaryPublicDNSName
+		if ipAddress == "" {
+			ipAddress = input.primaryPublicAddress
+		}
+	case connectionInterfacePrivateDNS:
+		ipAddress = input.primaryPrivateDNSName
+		if ipAddress == "" {
+			ipAddress = input.primaryPrivateAddress
+		}
+	default:
+		ipAddress = input.primaryPublicAddress
+		if ipAddress == "" {
+			ipAddress = input.primaryPrivateAddress
+		}
 	}
 
 	d.SetConnInfo(map[string]string{
@@ -137,3 +578,33 @@ func setConnectionInformation(d *schema.ResourceData, input connectionInfo, isWi
 		"host": ipAddress,
 	})
 }
+
+// setIPAddressAttributes populates the computed `private_ip_address`, `private_ip_addresses`,
+// `public_ip_address`, `public_ip_addresses`, `private_ip_addresses_v6`, `public_ip_addresses_v6`
+// and `public_ip_dns_names` attributes from the given connection info. This is intended to be
+// called alongside `setConnectionInformation` from the Read functions of
+// `azurerm_linux_virtual_machine` / `azurerm_windows_virtual_machine` and their data sources.
+func setIPAddressAttributes(d *schema.ResourceData, input connectionInfo) error {
+	if err := d.Set("private_ip_address", input.primaryPrivateAddress); err != nil {
+		return fmt.Errorf("setting `private_ip_address`: %+v", err)
+	}
+	if err := d.Set("private_ip_addresses", input.privateAddresses); err != nil {
+		return fmt.Errorf("setting `private_ip_addresses`: %+v", err)
+	}
+	if err := d.Set("public_ip_address", input.primaryPublicAddress); err != nil {
+		return fmt.Errorf("setting `public_ip_address`: %+v", err)
+	}
+	if err := d.Set("public_ip_addresses", input.publicAddresses); err != nil {
+		return fmt.Errorf("setting `public_ip_addresses`: %+v", err)
+	}
+	if err := d.Set("private_ip_addresses_v6", input.privateAddressesV6); err != nil {
+		return fmt.Errorf("setting `private_ip_addresses_v6`: %+v", err)
+	}
+	if err := d.Set("public_ip_addresses_v6", input.publicAddressesV6); err != nil {
+		return fmt.Errorf("setting `public_ip_addresses_v6`: %+v", err)
+	}
+	if err := d.Set("public_ip_dns_names", input.publicDNSNames); err != nil {
+		return fmt.Errorf("setting `public_ip_dns_names`: %+v", err)
+	}
+	return nil
+}
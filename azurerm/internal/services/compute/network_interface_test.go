@@ -0,0 +1,389 @@
+package compute
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func stringPtr(s string) *string { return &s }
+
+func TestExtractInterfaceDetails_classifiesIPv4AndIPv6Separately(t *testing.T) {
+	nic := network.Interface{
+		InterfacePropertiesFormat: &network.InterfacePropertiesFormat{
+			IPConfigurations: &[]network.InterfaceIPConfiguration{
+				{
+					InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+						PrivateIPAddress:        stringPtr("10.0.0.4"),
+						PrivateIPAddressVersion: network.IPv4,
+						PublicIPAddress: &network.PublicIPAddress{
+							PublicIPAddressPropertiesFormat: &network.PublicIPAddressPropertiesFormat{
+								IPAddress:              stringPtr("52.1.2.3"),
+								PublicIPAddressVersion: network.IPv4,
+							},
+						},
+					},
+				},
+				{
+					InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+						PrivateIPAddress:        stringPtr("fd00::4"),
+						PrivateIPAddressVersion: network.IPv6,
+						PublicIPAddress: &network.PublicIPAddress{
+							PublicIPAddressPropertiesFormat: &network.PublicIPAddressPropertiesFormat{
+								IPAddress:              stringPtr("2001:db8::3"),
+								PublicIPAddressVersion: network.IPv6,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	got := extractInterfaceDetails(nic)
+
+	if len(got.privateIPAddresses) != 1 || got.privateIPAddresses[0] != "10.0.0.4" {
+		t.Errorf("expected privateIPAddresses [10.0.0.4], got %v", got.privateIPAddresses)
+	}
+	if len(got.publicIPAddresses) != 1 || got.publicIPAddresses[0] != "52.1.2.3" {
+		t.Errorf("expected publicIPAddresses [52.1.2.3], got %v", got.publicIPAddresses)
+	}
+	if len(got.privateIPAddressesV6) != 1 || got.privateIPAddressesV6[0] != "fd00::4" {
+		t.Errorf("expected privateIPAddressesV6 [fd00::4], got %v", got.privateIPAddressesV6)
+	}
+	if len(got.publicIPAddressesV6) != 1 || got.publicIPAddressesV6[0] != "2001:db8::3" {
+		t.Errorf("expected publicIPAddressesV6 [2001:db8::3], got %v", got.publicIPAddressesV6)
+	}
+}
+
+func TestConnectionInterfaceSchema_defaultsToAutoAndValidatesValues(t *testing.T) {
+	s := connectionInterfaceSchema()
+
+	if s.Default != string(connectionInterfaceAuto) {
+		t.Errorf("expected default %q, got %v", connectionInterfaceAuto, s.Default)
+	}
+
+	valid := []string{"auto", "public_ip", "private_ip", "public_ipv6", "private_ipv6", "public_dns", "private_dns"}
+	for _, v := range valid {
+		if _, errs := s.ValidateFunc(v, "connection_interface"); len(errs) != 0 {
+			t.Errorf("expected %q to be a valid connection_interface, got errors %v", v, errs)
+		}
+	}
+
+	if _, errs := s.ValidateFunc("not_a_real_value", "connection_interface"); len(errs) == 0 {
+		t.Error("expected an invalid connection_interface value to produce a validation error")
+	}
+}
+
+func TestExpandConnectionIPSelector_readsBothFields(t *testing.T) {
+	resourceSchema := map[string]*schema.Schema{
+		"connection_interface":          connectionInterfaceSchema(),
+		"connection_interface_nic_name": connectionInterfaceNICNameSchema(),
+	}
+	d := schema.TestResourceDataRaw(t, resourceSchema, map[string]interface{}{
+		"connection_interface":          "private_ip",
+		"connection_interface_nic_name": "nic-a",
+	})
+
+	got := expandConnectionIPSelector(d)
+
+	if got.interfaceType != connectionInterfacePrivateIP {
+		t.Errorf("expected interfaceType %q, got %q", connectionInterfacePrivateIP, got.interfaceType)
+	}
+	if got.nicName != "nic-a" {
+		t.Errorf("expected nicName nic-a, got %q", got.nicName)
+	}
+}
+
+func TestIPAddressAttributesSchema_setIPAddressAttributesRoundTrips(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, ipAddressAttributesSchema(), map[string]interface{}{})
+
+	info := connectionInfo{
+		primaryPrivateAddress:   "10.0.0.4",
+		privateAddresses:        []string{"10.0.0.4", "10.0.0.5"},
+		primaryPublicAddress:    "52.1.2.3",
+		publicAddresses:         []string{"52.1.2.3"},
+		privateAddressesV6:      []string{"fd00::4"},
+		publicAddressesV6:       []string{"2001:db8::3"},
+		publicDNSNames:          []string{"example.westeurope.cloudapp.azure.com"},
+	}
+
+	if err := setIPAddressAttributes(d, info); err != nil {
+		t.Fatalf("setIPAddressAttributes returned an error: %+v", err)
+	}
+
+	if got := d.Get("private_ip_address").(string); got != "10.0.0.4" {
+		t.Errorf("expected private_ip_address 10.0.0.4, got %q", got)
+	}
+	if got := d.Get("public_ip_address").(string); got != "52.1.2.3" {
+		t.Errorf("expected public_ip_address 52.1.2.3, got %q", got)
+	}
+	if got := d.Get("private_ip_addresses").([]interface{}); len(got) != 2 {
+		t.Errorf("expected 2 private_ip_addresses, got %v", got)
+	}
+	if got := d.Get("public_ip_addresses_v6").([]interface{}); len(got) != 1 || got[0].(string) != "2001:db8::3" {
+		t.Errorf("expected public_ip_addresses_v6 [2001:db8::3], got %v", got)
+	}
+	if got := d.Get("public_ip_dns_names").([]interface{}); len(got) != 1 || got[0].(string) != "example.westeurope.cloudapp.azure.com" {
+		t.Errorf("expected public_ip_dns_names [example.westeurope.cloudapp.azure.com], got %v", got)
+	}
+}
+
+func TestMatchesConnectionSelector(t *testing.T) {
+	cases := []struct {
+		name     string
+		nicName  string
+		selector *connectionIPSelector
+		want     bool
+	}{
+		{"nil selector matches everything", "nic-a", nil, true},
+		{"empty nicName matches everything", "nic-a", &connectionIPSelector{interfaceType: connectionInterfaceAuto}, true},
+		{"matching nicName matches", "nic-a", &connectionIPSelector{nicName: "nic-a"}, true},
+		{"non-matching nicName is excluded", "nic-b", &connectionIPSelector{nicName: "nic-a"}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := matchesConnectionSelector(tc.nicName, tc.selector); got != tc.want {
+				t.Errorf("matchesConnectionSelector(%q, %+v) = %v, want %v", tc.nicName, tc.selector, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNICConnectionCache_getSet(t *testing.T) {
+	cache := newNICConnectionCache()
+
+	if _, ok := cache.get("nic-1"); ok {
+		t.Fatal("expected a miss on an empty cache")
+	}
+
+	details := &interfaceDetails{privateIPAddresses: []string{"10.0.0.4"}}
+	cache.set("nic-1", details)
+
+	got, ok := cache.get("nic-1")
+	if !ok {
+		t.Fatal("expected a hit after set")
+	}
+	if got != details {
+		t.Errorf("expected the cached pointer to be returned unchanged, got %+v", got)
+	}
+}
+
+func TestExtractInterfaceDetails_collectsPublicDNSFqdn(t *testing.T) {
+	nic := network.Interface{
+		InterfacePropertiesFormat: &network.InterfacePropertiesFormat{
+			DNSSettings: &network.InterfaceDNSSettings{
+				InternalDomainNameSuffix: stringPtr("internal.cloudapp.net"),
+			},
+			IPConfigurations: &[]network.InterfaceIPConfiguration{
+				{
+					InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+						PublicIPAddress: &network.PublicIPAddress{
+							PublicIPAddressPropertiesFormat: &network.PublicIPAddressPropertiesFormat{
+								IPAddress: stringPtr("52.1.2.3"),
+								DNSSettings: &network.PublicIPAddressDNSSettings{
+									Fqdn: stringPtr("example.westeurope.cloudapp.azure.com"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	got := extractInterfaceDetails(nic)
+
+	if len(got.publicDNSNames) != 1 || got.publicDNSNames[0] != "example.westeurope.cloudapp.azure.com" {
+		t.Errorf("expected publicDNSNames [example.westeurope.cloudapp.azure.com], got %v", got.publicDNSNames)
+	}
+	if got.internalDomainNameSuffix != "internal.cloudapp.net" {
+		t.Errorf("expected internalDomainNameSuffix internal.cloudapp.net, got %q", got.internalDomainNameSuffix)
+	}
+}
+
+func TestBuildConnectionInfo_derivesPrimaryPrivateDNSNameFromNICNameAndSuffix(t *testing.T) {
+	nics := []nicConnectionDetails{
+		{
+			name:      "vm1-nic",
+			isPrimary: true,
+			details: &interfaceDetails{
+				internalDomainNameSuffix: "internal.cloudapp.net",
+				publicDNSNames:           []string{"example.westeurope.cloudapp.azure.com"},
+			},
+		},
+	}
+
+	got := buildConnectionInfo(nics)
+
+	if got.primaryPrivateDNSName != "vm1-nic.internal.cloudapp.net" {
+		t.Errorf("expected primaryPrivateDNSName vm1-nic.internal.cloudapp.net, got %q", got.primaryPrivateDNSName)
+	}
+	if got.primaryPublicDNSName != "example.westeurope.cloudapp.azure.com" {
+		t.Errorf("expected primaryPublicDNSName example.westeurope.cloudapp.azure.com, got %q", got.primaryPublicDNSName)
+	}
+}
+
+func TestExtractInterfaceDetails_nilPropertiesReturnsEmptyDetails(t *testing.T) {
+	got := extractInterfaceDetails(network.Interface{})
+	if got == nil {
+		t.Fatal("expected a non-nil interfaceDetails")
+	}
+	if len(got.privateIPAddresses) != 0 || len(got.publicIPAddresses) != 0 {
+		t.Errorf("expected no addresses when InterfacePropertiesFormat is nil, got %+v", got)
+	}
+}
+
+func TestSetConnectionInformation_interfaceSelection(t *testing.T) {
+	info := connectionInfo{
+		primaryPrivateAddress:   "10.0.0.4",
+		primaryPublicAddress:    "52.1.2.3",
+		primaryPrivateAddressV6: "fd00::4",
+		primaryPublicAddressV6:  "2001:db8::3",
+		primaryPublicDNSName:    "example.westeurope.cloudapp.azure.com",
+		primaryPrivateDNSName:   "vm1.internal.cloudapp.net",
+	}
+
+	cases := []struct {
+		name      string
+		selector  *connectionIPSelector
+		isWindows bool
+		wantType  string
+		wantHost  string
+	}{
+		{
+			name:     "nil selector defaults to auto (public over private)",
+			selector: nil,
+			wantType: "ssh",
+			wantHost: "52.1.2.3",
+		},
+		{
+			name:     "public_ip",
+			selector: &connectionIPSelector{interfaceType: connectionInterfacePublicIP},
+			wantType: "ssh",
+			wantHost: "52.1.2.3",
+		},
+		{
+			name:     "private_ip",
+			selector: &connectionIPSelector{interfaceType: connectionInterfacePrivateIP},
+			wantType: "ssh",
+			wantHost: "10.0.0.4",
+		},
+		{
+			name:      "windows uses winrm provisioner",
+			selector:  &connectionIPSelector{interfaceType: connectionInterfacePrivateIP},
+			isWindows: true,
+			wantType:  "winrm",
+			wantHost:  "10.0.0.4",
+		},
+		{
+			name:     "public_ipv6",
+			selector: &connectionIPSelector{interfaceType: connectionInterfacePublicIPv6},
+			wantType: "ssh",
+			wantHost: "2001:db8::3",
+		},
+		{
+			name:     "private_ipv6",
+			selector: &connectionIPSelector{interfaceType: connectionInterfacePrivateIPv6},
+			wantType: "ssh",
+			wantHost: "fd00::4",
+		},
+		{
+			name:     "public_dns",
+			selector: &connectionIPSelector{interfaceType: connectionInterfacePublicDNS},
+			wantType: "ssh",
+			wantHost: "example.westeurope.cloudapp.azure.com",
+		},
+		{
+			name:     "private_dns",
+			selector: &connectionIPSelector{interfaceType: connectionInterfacePrivateDNS},
+			wantType: "ssh",
+			wantHost: "vm1.internal.cloudapp.net",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, map[string]*schema.Schema{}, map[string]interface{}{})
+			setConnectionInformation(d, info, tc.isWindows, tc.selector)
+
+			got := d.ConnInfo()
+			if got["type"] != tc.wantType {
+				t.Errorf("expected connection type %q, got %q", tc.wantType, got["type"])
+			}
+			if got["host"] != tc.wantHost {
+				t.Errorf("expected connection host %q, got %q", tc.wantHost, got["host"])
+			}
+		})
+	}
+}
+
+func TestBuildConnectionInfo_ordersPrimaryFirstThenByName(t *testing.T) {
+	nics := []nicConnectionDetails{
+		{name: "nic-b", isPrimary: false, details: &interfaceDetails{privateIPAddresses: []string{"10.0.0.6"}}},
+		{name: "nic-a", isPrimary: false, details: &interfaceDetails{privateIPAddresses: []string{"10.0.0.5"}}},
+		{name: "nic-c", isPrimary: true, details: &interfaceDetails{privateIPAddresses: []string{"10.0.0.4"}, publicIPAddresses: []string{"52.1.2.3"}}},
+	}
+
+	got := buildConnectionInfo(nics)
+
+	// the primary NIC (nic-c) must be first regardless of name, so its address is both the
+	// primary and the first entry in the flattened list
+	if got.primaryPrivateAddress != "10.0.0.4" {
+		t.Errorf("expected primary private address 10.0.0.4, got %q", got.primaryPrivateAddress)
+	}
+	if got.primaryPublicAddress != "52.1.2.3" {
+		t.Errorf("expected primary public address 52.1.2.3, got %q", got.primaryPublicAddress)
+	}
+
+	wantOrder := []string{"10.0.0.4", "10.0.0.5", "10.0.0.6"}
+	if len(got.privateAddresses) != len(wantOrder) {
+		t.Fatalf("expected %d private addresses, got %d (%v)", len(wantOrder), len(got.privateAddresses), got.privateAddresses)
+	}
+	for i, want := range wantOrder {
+		if got.privateAddresses[i] != want {
+			t.Errorf("expected privateAddresses[%d] = %q, got %q", i, want, got.privateAddresses[i])
+		}
+	}
+}
+
+func TestBuildConnectionInfo_noNICsReturnsEmptyInfo(t *testing.T) {
+	got := buildConnectionInfo(nil)
+	if got.primaryPrivateAddress != "" || got.primaryPublicAddress != "" {
+		t.Errorf("expected empty connectionInfo for no NICs, got %+v", got)
+	}
+	if len(got.privateAddresses) != 0 || len(got.publicAddresses) != 0 {
+		t.Errorf("expected empty address slices for no NICs, got %+v", got)
+	}
+}
+
+func TestSetConnectionInformation_v6FallsBackToV4WhenAbsent(t *testing.T) {
+	info := connectionInfo{
+		primaryPrivateAddress: "10.0.0.4",
+		primaryPublicAddress:  "52.1.2.3",
+	}
+
+	cases := []struct {
+		name     string
+		selector *connectionIPSelector
+		wantHost string
+	}{
+		{"public_ipv6 falls back to public_ip", &connectionIPSelector{interfaceType: connectionInterfacePublicIPv6}, "52.1.2.3"},
+		{"private_ipv6 falls back to private_ip", &connectionIPSelector{interfaceType: connectionInterfacePrivateIPv6}, "10.0.0.4"},
+		{"public_dns falls back to public_ip", &connectionIPSelector{interfaceType: connectionInterfacePublicDNS}, "52.1.2.3"},
+		{"private_dns falls back to private_ip", &connectionIPSelector{interfaceType: connectionInterfacePrivateDNS}, "10.0.0.4"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, map[string]*schema.Schema{}, map[string]interface{}{})
+			setConnectionInformation(d, info, false, tc.selector)
+
+			if got := d.ConnInfo()["host"]; got != tc.wantHost {
+				t.Errorf("expected connection host %q, got %q", tc.wantHost, got)
+			}
+		})
+	}
+}